@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// --------------------------------------------------
+// BATCH EXTRACTION
+// --------------------------------------------------
+
+// BatchItem is a single unit of work in a /extract/batch request.
+type BatchItem struct {
+	ID      string `json:"id"`
+	RawText string `json:"raw_text"`
+}
+
+// BatchRequest is the payload accepted by /extract/batch. DeadlineMs, if
+// set, bounds each item individually rather than the batch as a whole.
+type BatchRequest struct {
+	Items      []BatchItem `json:"items"`
+	DeadlineMs int         `json:"deadline_ms"`
+}
+
+// BatchItemResponse pairs an ExtractionResponse with the id it was
+// requested under, since batch items complete out of order.
+type BatchItemResponse struct {
+	ID string `json:"id"`
+	ExtractionResponse
+}
+
+// batchHandler fans work out across a worker pool sized to GOMAXPROCS.
+// Items that don't finish within DeadlineMs come back with Status
+// "timeout" instead of failing the batch.
+func batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Items) == 0 {
+		http.Error(w, "items must not be empty", http.StatusBadRequest)
+		return
+	}
+
+	idx := globalIndex.Load()
+	cfg := *globalMatchConfig.Load()
+	results := make([]BatchItemResponse, len(req.Items))
+
+	type job struct {
+		pos  int
+		item BatchItem
+	}
+	jobs := make(chan job)
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(req.Items) {
+		workers = len(req.Items)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j.pos] = extractBatchItem(r.Context(), idx, cfg, j.item, req.DeadlineMs)
+			}
+		}()
+	}
+
+	for i, item := range req.Items {
+		jobs <- job{pos: i, item: item}
+	}
+	close(jobs)
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(results)
+}
+
+// extractBatchItem runs a single batch item's extraction under ctx, bounded
+// by deadlineMs when positive.
+func extractBatchItem(ctx context.Context, idx *Index, cfg MatchConfig, item BatchItem, deadlineMs int) BatchItemResponse {
+	if deadlineMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(deadlineMs)*time.Millisecond)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result := idx.Match(ctx, item.RawText, cfg)
+	elapsed := time.Since(start)
+
+	return BatchItemResponse{
+		ID: item.ID,
+		ExtractionResponse: ExtractionResponse{
+			Product:   result.Product,
+			Brand:     result.Brand,
+			Category:  result.Category,
+			Status:    result.Status,
+			TimeTaken: formatElapsed(elapsed),
+		},
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// --------------------------------------------------
+// PLUGGABLE SCORING
+// --------------------------------------------------
+
+// Scorer computes a 0-100 similarity score between two normalized strings;
+// higher means more similar. Score must be deterministic for a given (a, b)
+// pair so candidatesForToken's distance pruning and Match's ranking agree.
+type Scorer interface {
+	Name() string
+	Score(a, b string) int
+}
+
+// scorers is the registry of Scorer implementations selectable by name via
+// MatchConfig.ScorerName or a per-request "scorer" override.
+var scorers = map[string]Scorer{
+	"levenshtein":  levenshteinScorer{},
+	"jaro_winkler": jaroWinklerScorer{},
+	"token_set":    tokenSetScorer{},
+}
+
+// levenshteinScorer is the original metric: fuzzRatio derived from edit
+// distance over the full normalized strings.
+type levenshteinScorer struct{}
+
+func (levenshteinScorer) Name() string { return "levenshtein" }
+
+func (levenshteinScorer) Score(a, b string) int { return fuzzRatio(a, b) }
+
+// jaroWinklerScorer favors strings that share a common prefix, which tends
+// to suit product names more than raw edit distance does.
+type jaroWinklerScorer struct{}
+
+func (jaroWinklerScorer) Name() string { return "jaro_winkler" }
+
+func (jaroWinklerScorer) Score(a, b string) int {
+	return int(jaroWinklerSimilarity(a, b) * 100)
+}
+
+// jaroWinklerSimilarity returns the Jaro-Winkler similarity of a and b in
+// [0, 1].
+func jaroWinklerSimilarity(a, b string) float64 {
+	j := jaroSimilarity(a, b)
+	if j <= 0 {
+		return j
+	}
+
+	r1, r2 := []rune(a), []rune(b)
+	const maxPrefix = 4
+	const prefixWeight = 0.1
+
+	prefix := 0
+	for prefix < maxPrefix && prefix < len(r1) && prefix < len(r2) && r1[prefix] == r2[prefix] {
+		prefix++
+	}
+
+	return j + float64(prefix)*prefixWeight*(1-j)
+}
+
+// jaroSimilarity returns the Jaro similarity of a and b in [0, 1].
+func jaroSimilarity(a, b string) float64 {
+	r1, r2 := []rune(a), []rune(b)
+	len1, len2 := len(r1), len(r2)
+	if len1 == 0 && len2 == 0 {
+		return 1
+	}
+	if len1 == 0 || len2 == 0 {
+		return 0
+	}
+
+	matchDistance := len1
+	if len2 > matchDistance {
+		matchDistance = len2
+	}
+	matchDistance = matchDistance/2 - 1
+	if matchDistance < 0 {
+		matchDistance = 0
+	}
+
+	s1Matches := make([]bool, len1)
+	s2Matches := make([]bool, len2)
+	matches := 0
+
+	for i := 0; i < len1; i++ {
+		start := i - matchDistance
+		if start < 0 {
+			start = 0
+		}
+		end := i + matchDistance + 1
+		if end > len2 {
+			end = len2
+		}
+		for j := start; j < end; j++ {
+			if s2Matches[j] || r1[i] != r2[j] {
+				continue
+			}
+			s1Matches[i] = true
+			s2Matches[j] = true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions := 0
+	k := 0
+	for i := 0; i < len1; i++ {
+		if !s1Matches[i] {
+			continue
+		}
+		for !s2Matches[k] {
+			k++
+		}
+		if r1[i] != r2[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	t := float64(transpositions) / 2
+	return (m/float64(len1) + m/float64(len2) + (m-t)/m) / 3
+}
+
+// tokenSetScorer is a rapidfuzz-style token-set ratio: it splits both
+// strings into token sets and compares the shared tokens against each
+// side's leftover tokens, so word order and duplicated/missing tokens
+// matter less than they do for plain edit distance.
+type tokenSetScorer struct{}
+
+func (tokenSetScorer) Name() string { return "token_set" }
+
+func (tokenSetScorer) Score(a, b string) int {
+	set1 := tokenSet(a)
+	set2 := tokenSet(b)
+
+	var intersection, diff1, diff2 []string
+	for tok := range set1 {
+		if set2[tok] {
+			intersection = append(intersection, tok)
+		} else {
+			diff1 = append(diff1, tok)
+		}
+	}
+	for tok := range set2 {
+		if !set1[tok] {
+			diff2 = append(diff2, tok)
+		}
+	}
+	sort.Strings(intersection)
+	sort.Strings(diff1)
+	sort.Strings(diff2)
+
+	sect := strings.Join(intersection, " ")
+	combined1 := strings.TrimSpace(sect + " " + strings.Join(diff1, " "))
+	combined2 := strings.TrimSpace(sect + " " + strings.Join(diff2, " "))
+
+	best := fuzzRatio(sect, combined1)
+	for _, score := range []int{fuzzRatio(sect, combined2), fuzzRatio(combined1, combined2)} {
+		if score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+// tokenSet returns the distinct whitespace-separated tokens of s.
+func tokenSet(s string) map[string]bool {
+	fields := strings.Fields(s)
+	set := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		set[f] = true
+	}
+	return set
+}
+
+// --------------------------------------------------
+// MATCH CONFIG
+// --------------------------------------------------
+
+// MatchConfig holds the scoring knobs Index.Match uses: which Scorer to
+// run, the fuzzy-match cutoff, the max-candidates threshold above which a
+// request is reported unmatched, and how to break a tied top score.
+type MatchConfig struct {
+	ScorerName     string `json:"scorer"`
+	FuzzyThreshold int    `json:"fuzzy_threshold"`
+	MaxCandidates  int    `json:"max_candidates"`
+	TieBreaker     string `json:"tie_breaker"`
+}
+
+// defaultMatchConfig is used when no config file or env vars are set.
+var defaultMatchConfig = MatchConfig{
+	ScorerName:     "levenshtein",
+	FuzzyThreshold: 90,
+	MaxCandidates:  10,
+	TieBreaker:     "first",
+}
+
+// scorer resolves c.ScorerName against the registry, falling back to the
+// default scorer for an unknown or empty name.
+func (c MatchConfig) scorer() Scorer {
+	if s, ok := scorers[c.ScorerName]; ok {
+		return s
+	}
+	return scorers[defaultMatchConfig.ScorerName]
+}
+
+// withOverrides returns a copy of c with any non-zero-value fields of o
+// applied, for the per-request "scorer"/"fuzzy_threshold" overrides on
+// ExtractionRequest.
+func (c MatchConfig) withOverrides(scorerName string, fuzzyThreshold int) MatchConfig {
+	out := c
+	if scorerName != "" {
+		out.ScorerName = scorerName
+	}
+	if fuzzyThreshold > 0 {
+		out.FuzzyThreshold = fuzzyThreshold
+	}
+	return out
+}
+
+// loadMatchConfig builds the startup MatchConfig from, in increasing
+// precedence: defaultMatchConfig, an optional JSON file named by the
+// MATCH_CONFIG_PATH env var, then individual env var overrides
+// (SCORER_NAME, FUZZY_THRESHOLD, MAX_CANDIDATES, TIE_BREAKER). A missing
+// or invalid config file just falls back to the defaults rather than
+// failing startup.
+func loadMatchConfig() MatchConfig {
+	cfg := defaultMatchConfig
+
+	if path := os.Getenv("MATCH_CONFIG_PATH"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("match config %s: %v, using defaults", path, err)
+		} else if err := json.Unmarshal(data, &cfg); err != nil {
+			log.Printf("match config %s: %v, using defaults", path, err)
+		}
+	}
+
+	if v := os.Getenv("SCORER_NAME"); v != "" {
+		cfg.ScorerName = v
+	}
+	if v := os.Getenv("FUZZY_THRESHOLD"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.FuzzyThreshold = n
+		}
+	}
+	if v := os.Getenv("MAX_CANDIDATES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.MaxCandidates = n
+		}
+	}
+	if v := os.Getenv("TIE_BREAKER"); v != "" {
+		cfg.TieBreaker = v
+	}
+
+	return cfg
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// --------------------------------------------------
+// FUZZ HARNESS
+// --------------------------------------------------
+//
+// FuzzNormalize and FuzzExtract exercise the two functions most exposed to
+// arbitrary caller input: normalize, which every raw_text passes through
+// before anything else touches it, and Index.Match, which walks that
+// output through the index/BK-tree/scorer pipeline. Both mirror the
+// "parse then re-encode round-trip" style used to fuzz mime.ParseMediaType
+// in the standard library: assert the invariants the rest of the package
+// relies on rather than any particular output value.
+
+func FuzzNormalize(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"Samsung Galaxy S21 128GB",
+		"<b>Product</b> &amp; More",
+		"café über 日本語",
+		"\xff\xfe\x00invalid utf8",
+		"   \t\n  ",
+		"ALL CAPS 4K 60FPS 5000MAH",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		out := normalize(raw)
+
+		if again := normalize(out); again != out {
+			t.Fatalf("normalize not idempotent for %q: got %q, then %q", raw, out, again)
+		}
+
+		for _, r := range out {
+			if r != ' ' && !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') {
+				t.Fatalf("normalize(%q) = %q contains disallowed rune %q", raw, out, r)
+			}
+		}
+	})
+}
+
+// fuzzScoreCalls counts Score calls made through fuzzCountingScorer, reset
+// before each FuzzExtract iteration so it bounds a single Match call
+// rather than the whole fuzz run.
+var fuzzScoreCalls int64
+
+// fuzzCountingScorer wraps the default levenshtein scorer so FuzzExtract
+// can assert Match's scoring passes stay bounded, even on adversarial
+// input. It registers under the "levenshtein" name (not a distinct one)
+// so it still gets the BK-tree-accelerated Phase-1 path in
+// candidatesForToken, which is keyed off Scorer.Name().
+type fuzzCountingScorer struct{}
+
+func (fuzzCountingScorer) Name() string { return defaultMatchConfig.ScorerName }
+
+func (fuzzCountingScorer) Score(a, b string) int {
+	atomic.AddInt64(&fuzzScoreCalls, 1)
+	return fuzzRatio(a, b)
+}
+
+func init() {
+	scorers[defaultMatchConfig.ScorerName] = fuzzCountingScorer{}
+}
+
+// validStatuses is every Status Match can return.
+var validStatuses = map[string]bool{
+	"no_match":                      true,
+	"matched_fuzzy_max":             true,
+	"unmatched_too_many_candidates": true,
+	"timeout":                       true,
+}
+
+func fuzzExtractDictionary() []ProductRow {
+	rows := []ProductRow{
+		{Product: "Samsung Galaxy S21 128GB", Brand: "Samsung", Category: "Phones"},
+		{Product: "Apple iPhone 13 256GB", Brand: "Apple", Category: "Phones"},
+		{Product: "Sony WH-1000XM4 Headphones", Brand: "Sony", Category: "Audio"},
+		{Product: "Dell XPS 13 Laptop", Brand: "Dell", Category: "Laptops"},
+	}
+	for i := range rows {
+		rows[i].NormProduct = normalize(rows[i].Product)
+		rows[i].TokenLen = len(strings.Fields(rows[i].NormProduct))
+	}
+	return rows
+}
+
+func FuzzExtract(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		"Samsung Galaxy S21 128GB",
+		"samsng galxy s21",
+		"0000000000000000000000000000000000000000",
+		"\xff\xfe garbled \x00 bytes",
+		"日本語 русский english mix",
+	} {
+		f.Add(seed)
+	}
+
+	idx := &Index{}
+	idx.Build(fuzzExtractDictionary())
+
+	cfg := defaultMatchConfig
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		atomic.StoreInt64(&fuzzScoreCalls, 0)
+
+		result := idx.Match(context.Background(), raw, cfg)
+
+		if !validStatuses[result.Status] {
+			t.Fatalf("Match(%q) returned unknown status %q", raw, result.Status)
+		}
+		if result.Product != nil && (result.Brand == nil || result.Category == nil) {
+			t.Fatalf("Match(%q) returned Product %q with nil Brand/Category", raw, *result.Product)
+		}
+
+		// Phase 1 scores at most len(fuzzyTokens) candidates per raw
+		// token, Phase 2 at most MaxCandidates - 1; bound the total
+		// against those rather than a single dictionary-size-independent
+		// constant, since raw text can contain arbitrarily many tokens.
+		numRawTokens := len(strings.Fields(normalize(raw)))
+		bound := int64(numRawTokens*len(idx.fuzzyTokens) + cfg.MaxCandidates)
+		if calls := atomic.LoadInt64(&fuzzScoreCalls); calls > bound {
+			t.Fatalf("Match(%q) made %d scorer calls, want <= %d", raw, calls, bound)
+		}
+	})
+}
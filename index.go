@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+)
+
+// --------------------------------------------------
+// INVERTED INDEX + BK-TREE MATCHING
+// --------------------------------------------------
+
+// bkNode is a single node of a BK-tree, keyed by Levenshtein distance from
+// its parent.
+type bkNode struct {
+	term     string
+	children map[int]*bkNode
+}
+
+// bkTree is a Burkhard-Keller tree over a metric space (Levenshtein
+// distance) that answers "all terms within distance d of x" without
+// comparing x against every indexed term.
+type bkTree struct {
+	root *bkNode
+}
+
+func (t *bkTree) insert(term string) {
+	if t.root == nil {
+		t.root = &bkNode{term: term}
+		return
+	}
+	node := t.root
+	for {
+		dist := levenshteinDistance(node.term, term)
+		if dist == 0 {
+			return
+		}
+		if node.children == nil {
+			node.children = make(map[int]*bkNode)
+		}
+		child, ok := node.children[dist]
+		if !ok {
+			node.children[dist] = &bkNode{term: term}
+			return
+		}
+		node = child
+	}
+}
+
+// query returns every indexed term within maxDist of term, pruning
+// subtrees via the triangle inequality.
+func (t *bkTree) query(term string, maxDist int) []string {
+	if t.root == nil {
+		return nil
+	}
+	var matches []string
+	var visit func(node *bkNode)
+	visit = func(node *bkNode) {
+		dist := levenshteinDistance(node.term, term)
+		if dist <= maxDist {
+			matches = append(matches, node.term)
+		}
+		for d, child := range node.children {
+			if d >= dist-maxDist && d <= dist+maxDist {
+				visit(child)
+			}
+		}
+	}
+	visit(t.root)
+	return matches
+}
+
+// Index is the prebuilt matching structure for a dictionary.
+type Index struct {
+	rows             []ProductRow
+	exactTokens      map[string][]int // token -> row IDs containing it verbatim
+	fuzzyTokens      map[string][]int // every product token -> row IDs containing it
+	tree             *bkTree          // BK-tree over the keys of fuzzyTokens
+	maxFuzzyTokenLen int              // rune length of the longest key in fuzzyTokens
+}
+
+// Build populates the index from a dictionary of rows, replacing any
+// previously built state. fuzzyTokens indexes every product token
+// regardless of its own shape, since isModelToken only constrains how an
+// incoming raw token is looked up, not which product tokens can match it.
+func (idx *Index) Build(rows []ProductRow) {
+	idx.rows = rows
+	idx.exactTokens = make(map[string][]int)
+	idx.fuzzyTokens = make(map[string][]int)
+	idx.tree = &bkTree{}
+
+	for id, row := range rows {
+		for _, tok := range strings.Fields(row.NormProduct) {
+			idx.exactTokens[tok] = appendRowID(idx.exactTokens[tok], id)
+			if _, seen := idx.fuzzyTokens[tok]; !seen {
+				idx.tree.insert(tok)
+				if n := len([]rune(tok)); n > idx.maxFuzzyTokenLen {
+					idx.maxFuzzyTokenLen = n
+				}
+			}
+			idx.fuzzyTokens[tok] = appendRowID(idx.fuzzyTokens[tok], id)
+		}
+	}
+}
+
+// appendRowID appends id to ids, skipping the append when id is already
+// the last entry (rows are visited in order, so duplicates are adjacent).
+func appendRowID(ids []int, id int) []int {
+	if len(ids) > 0 && ids[len(ids)-1] == id {
+		return ids
+	}
+	return append(ids, id)
+}
+
+// candidatesForToken returns the row IDs that plausibly match token: the
+// exact-token index for model tokens, otherwise every fuzzy token passing
+// cfg.scorer().Score(token, term) >= cfg.FuzzyThreshold. The BK-tree is
+// keyed by Levenshtein distance, so it can only accelerate the
+// levenshtein scorer; any other configured scorer falls back to scoring
+// every indexed fuzzy token directly, so a per-request scorer override
+// also governs which candidates survive this phase, not just their rank
+// in Match's Phase 2.
+func (idx *Index) candidatesForToken(token string, cfg MatchConfig) []int {
+	if isModelToken(token) {
+		return idx.exactTokens[token]
+	}
+
+	scorer := cfg.scorer()
+	var ids []int
+	seen := make(map[int]bool)
+
+	addTerm := func(term string) {
+		for _, id := range idx.fuzzyTokens[term] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	if scorer.Name() == defaultMatchConfig.ScorerName {
+		// levenshteinDistance keys the BK-tree, so query it with the
+		// loosest distance any indexed term could need (using
+		// maxFuzzyTokenLen as the candidate length) and filter the
+		// resulting superset down to the exact fuzzRatio cutoff, which
+		// depends on both token lengths.
+		l1 := len([]rune(token))
+		frac := 1 - float64(cfg.FuzzyThreshold)/100.0
+		maxDist := int(math.Ceil(float64(l1+idx.maxFuzzyTokenLen) * frac))
+		for _, term := range idx.tree.query(token, maxDist) {
+			if scorer.Score(token, term) >= cfg.FuzzyThreshold {
+				addTerm(term)
+			}
+		}
+	} else {
+		for term := range idx.fuzzyTokens {
+			if scorer.Score(token, term) >= cfg.FuzzyThreshold {
+				addTerm(term)
+			}
+		}
+	}
+
+	sort.Ints(ids)
+	return ids
+}
+
+// intersectIDs returns the sorted intersection of two sorted ID slices.
+func intersectIDs(a, b []int) []int {
+	var out []int
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// Match runs the two-phase extraction: narrow candidates via the inverted
+// index / BK-tree, then score the survivors with cfg's Scorer. ctx is
+// checked in both the Phase-1 filter loop and the Phase-2 scoring loop, so
+// a client disconnect or per-item deadline aborts remaining work promptly.
+func (idx *Index) Match(ctx context.Context, rawText string, cfg MatchConfig) Result {
+	cancel := ctx.Done()
+
+	rawNorm := normalize(rawText)
+	rawTokens := strings.Fields(rawNorm)
+
+	candidates := make([]int, len(idx.rows))
+	for i := range idx.rows {
+		candidates[i] = i
+	}
+
+	// Phase 1: Filter candidates based on raw tokens (Elimination)
+	for _, token := range rawTokens {
+		select {
+		case <-cancel:
+			return Result{Status: "timeout"}
+		default:
+		}
+
+		matched := idx.candidatesForToken(token, cfg)
+		if len(matched) == 0 {
+			continue
+		}
+
+		filtered := intersectIDs(candidates, matched)
+
+		// If filtering reduced the list but didn't empty it, update candidates
+		if len(filtered) > 0 {
+			candidates = filtered
+		}
+
+		// Optimization: If only 1 left, we are done
+		if len(candidates) == 1 {
+			break
+		}
+	}
+
+	// Phase 2: Result Decision
+	count := len(candidates)
+
+	if count == 0 {
+		return Result{Status: "no_match"}
+	}
+
+	// LOGIC CHANGE: Check if candidates < cfg.MaxCandidates (and > 0)
+	if count < cfg.MaxCandidates {
+		type scoredCandidate struct {
+			Row   ProductRow
+			Score int
+		}
+
+		scored := make([]scoredCandidate, 0, count)
+		scorer := cfg.scorer()
+
+		// Calculate Fuzzy Match Score for each candidate against the FULL Raw Text
+		for _, id := range candidates {
+			select {
+			case <-cancel:
+				return Result{Status: "timeout"}
+			default:
+			}
+
+			row := idx.rows[id]
+			score := scorer.Score(row.NormProduct, rawNorm)
+			scored = append(scored, scoredCandidate{Row: row, Score: score})
+		}
+
+		// Sort by Score (Descending), keeping candidate order stable on ties
+		// so TieBreaker "first" means "first candidate found".
+		sort.SliceStable(scored, func(i, j int) bool {
+			return scored[i].Score > scored[j].Score
+		})
+
+		best := scored[0]
+		for _, sc := range scored[1:] {
+			if sc.Score != best.Score {
+				break
+			}
+			switch cfg.TieBreaker {
+			case "shortest":
+				if sc.Row.TokenLen < best.Row.TokenLen {
+					best = sc
+				}
+			case "longest":
+				if sc.Row.TokenLen > best.Row.TokenLen {
+					best = sc
+				}
+			}
+		}
+
+		bestRow := best.Row
+		return Result{Product: &bestRow.Product, Brand: &bestRow.Brand, Category: &bestRow.Category, Status: "matched_fuzzy_max"}
+	}
+
+	// If count >= cfg.MaxCandidates
+	return Result{Status: "unmatched_too_many_candidates"}
+}
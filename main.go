@@ -5,12 +5,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"html"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"regexp"
-	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -18,17 +19,23 @@ import (
 // CONFIG & GLOBALS
 // --------------------------------------------------
 var (
-	defaultDictPath  = "barc_csv_file_here"
-	globalDictionary []ProductRow
+	defaultDictPath   = "barc_csv_file_here"
+	globalIndex       atomic.Pointer[Index]
+	globalMatchConfig atomic.Pointer[MatchConfig]
 )
 
 // --------------------------------------------------
 // API STRUCTS
 // --------------------------------------------------
 
-// ExtractionRequest represents the incoming JSON payload
+// ExtractionRequest represents the incoming JSON payload. Scorer and
+// FuzzyThreshold are optional per-request overrides of the startup
+// MatchConfig (see config.go), letting callers A/B test scorers without
+// redeploying.
 type ExtractionRequest struct {
-	RawText string `json:"raw_text"`
+	RawText        string `json:"raw_text"`
+	Scorer         string `json:"scorer,omitempty"`
+	FuzzyThreshold int    `json:"fuzzy_threshold,omitempty"`
 }
 
 // ExtractionResponse represents the API response
@@ -139,41 +146,65 @@ func fuzzRatio(s1, s2 string) int {
 	return int(float64(l1+l2-dist) / float64(l1+l2) * 100.0)
 }
 
-func tokenMatch(token string, productText string) bool {
-	productTokens := strings.Fields(productText)
-	if isModelToken(token) {
-		for _, pt := range productTokens {
-			if token == pt {
-				return true
-			}
-		}
-		return false
-	}
-	for _, pt := range productTokens {
-		if fuzzRatio(token, pt) >= 90 {
-			return true
-		}
-	}
-	return false
-}
-
 // --------------------------------------------------
 // 3. DATA LOADING
 // --------------------------------------------------
-func loadDictionary(path string) []ProductRow {
-	var dictRows []ProductRow
 
+// DictParseError reports where in a dictionary CSV parsing failed, so
+// callers like reloadHandler can surface the row/column to the operator
+// instead of a bare error string.
+type DictParseError struct {
+	Row    int
+	Column int
+	Err    error
+}
+
+func (e *DictParseError) Error() string {
+	return fmt.Sprintf("dictionary parse error at row %d, column %d: %v", e.Row, e.Column, e.Err)
+}
+
+func (e *DictParseError) Unwrap() error {
+	return e.Err
+}
+
+func loadDictionary(path string) []ProductRow {
 	file, err := os.Open(path)
 	if err != nil {
 		log.Fatal("Error reading CSV file:", err)
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	records, err := reader.ReadAll()
+	dictRows, err := parseDictionary(file)
 	if err != nil {
 		log.Fatal("Error reading CSV records:", err)
 	}
+	return dictRows
+}
+
+// parseDictionary reads a dictionary CSV from r and builds its ProductRow
+// set. Unlike loadDictionary it returns parse failures (as *DictParseError)
+// instead of exiting, so callers validating an uploaded or reloaded
+// dictionary can report the failure without killing the server.
+func parseDictionary(r io.Reader) ([]ProductRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	var records [][]string
+	line := 0
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if pe, ok := err.(*csv.ParseError); ok {
+				return nil, &DictParseError{Row: pe.StartLine, Column: pe.Column, Err: pe.Err}
+			}
+			return nil, &DictParseError{Row: line + 1, Err: err}
+		}
+		records = append(records, rec)
+		line++
+	}
 
 	// Simple header mapping
 	headers := map[string]int{}
@@ -183,6 +214,7 @@ func loadDictionary(path string) []ProductRow {
 		}
 	}
 
+	var dictRows []ProductRow
 	for i := 1; i < len(records); i++ {
 		row := records[i]
 		pIdx, bIdx, cIdx := 0, 1, 2
@@ -204,79 +236,19 @@ func loadDictionary(path string) []ProductRow {
 			})
 		}
 	}
-	return dictRows
+	return dictRows, nil
 }
 
 // --------------------------------------------------
-// 4. CORE LOGIC
+// 5. HTTP HANDLERS
 // --------------------------------------------------
-func extractProductFromRaw(rawText string, dictionary []ProductRow) Result {
-	rawNorm := normalize(rawText)
-	rawTokens := strings.Fields(rawNorm)
-
-	candidates := make([]ProductRow, len(dictionary))
-	copy(candidates, dictionary)
-
-	// Phase 1: Filter candidates based on raw tokens (Elimination)
-	for _, token := range rawTokens {
-		var filtered []ProductRow
-		for _, row := range candidates {
-			if tokenMatch(token, row.NormProduct) {
-				filtered = append(filtered, row)
-			}
-		}
-
-		// If filtering reduced the list but didn't empty it, update candidates
-		if len(filtered) > 0 {
-			candidates = filtered
-		}
-
-		// Optimization: If only 1 left, we are done
-		if len(candidates) == 1 {
-			break
-		}
-	}
-
-	// Phase 2: Result Decision
-	count := len(candidates)
-
-	if count == 0 {
-		return Result{Status: "no_match"}
-	}
-
-	// LOGIC CHANGE: Check if candidates < 10 (and > 0)
-	if count < 10 {
-		type ScoredCandidate struct {
-			Row   ProductRow
-			Score int
-		}
 
-		var scored []ScoredCandidate
-
-		// Calculate Fuzzy Match Score for each candidate against the FULL Raw Text
-		for _, cand := range candidates {
-			// Using fuzzRatio to compare candidate product vs raw text
-			score := fuzzRatio(cand.NormProduct, rawNorm)
-			scored = append(scored, ScoredCandidate{Row: cand, Score: score})
-		}
-
-		// Sort by Score (Descending)
-		sort.Slice(scored, func(i, j int) bool {
-			return scored[i].Score > scored[j].Score
-		})
-
-		best := scored[0].Row
-		return Result{Product: &best.Product, Brand: &best.Brand, Category: &best.Category, Status: "matched_fuzzy_max"}
-	}
-
-	// If count >= 10
-	return Result{Status: "unmatched_too_many_candidates"}
+// formatElapsed renders a duration the way ExtractionResponse.TimeTaken is
+// reported everywhere, e.g. "0.2124 ms".
+func formatElapsed(elapsed time.Duration) string {
+	return fmt.Sprintf("%.4f ms", float64(elapsed.Microseconds())/1000.0)
 }
 
-// --------------------------------------------------
-// 5. HTTP HANDLERS
-// --------------------------------------------------
-
 func extractHandler(w http.ResponseWriter, r *http.Request) {
 	// Only allow POST
 	if r.Method != http.MethodPost {
@@ -292,8 +264,10 @@ func extractHandler(w http.ResponseWriter, r *http.Request) {
 
 	start := time.Now()
 
+	cfg := globalMatchConfig.Load().withOverrides(req.Scorer, req.FuzzyThreshold)
+
 	// Core Logic
-	result := extractProductFromRaw(req.RawText, globalDictionary)
+	result := globalIndex.Load().Match(r.Context(), req.RawText, cfg)
 
 	elapsed := time.Since(start)
 
@@ -302,16 +276,23 @@ func extractHandler(w http.ResponseWriter, r *http.Request) {
 		Brand:     result.Brand,
 		Category:  result.Category,
 		Status:    result.Status,
-		TimeTaken: fmt.Sprintf("%.4f ms", float64(elapsed.Microseconds())/1000.0),
+		TimeTaken: formatElapsed(elapsed),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
+// healthHandler reports liveness plus the active MatchConfig, so operators
+// can confirm which scorer and thresholds a deploy is actually running
+// without cross-referencing env vars.
 func healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("OK"))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status": "ok",
+		"config": globalMatchConfig.Load(),
+	})
 }
 
 // --------------------------------------------------
@@ -326,12 +307,29 @@ func main() {
 
 	// 2. Load Data (ONCE at startup)
 	fmt.Printf("Loading dictionary from: %s\n", csvPath)
-	globalDictionary = loadDictionary(csvPath)
-	fmt.Printf("Dictionary loaded with %d items.\n", len(globalDictionary))
+	currentDictPath = csvPath
+	rows := loadDictionary(csvPath)
+	idx := &Index{}
+	idx.Build(rows)
+	globalIndex.Store(idx)
+	fmt.Printf("Dictionary loaded with %d items.\n", len(rows))
+
+	matchCfg := loadMatchConfig()
+	globalMatchConfig.Store(&matchCfg)
+	fmt.Printf("Match config: scorer=%s fuzzy_threshold=%d max_candidates=%d tie_breaker=%s\n",
+		matchCfg.ScorerName, matchCfg.FuzzyThreshold, matchCfg.MaxCandidates, matchCfg.TieBreaker)
+
+	// 2b. Optionally watch DICT_PATH for external edits
+	if os.Getenv("DICT_WATCH") != "" {
+		watchDictionary(csvPath)
+	}
 
 	// 3. Define Routes
 	http.HandleFunc("/extract", extractHandler)
+	http.HandleFunc("/extract/batch", batchHandler)
 	http.HandleFunc("/health", healthHandler)
+	http.HandleFunc("/reload", reloadHandler)
+	http.HandleFunc("/dictionary", dictionaryUploadHandler)
 
 	// 4. Start Server
 	port := "8080"
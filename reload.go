@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// --------------------------------------------------
+// HOT DICTIONARY RELOAD
+// --------------------------------------------------
+
+const reloadSecretHeader = "X-Reload-Secret"
+
+// currentDictPath is set once at startup and used by /reload and the
+// fsnotify watcher to know which file to re-parse.
+var currentDictPath string
+
+// uploadMu serializes /dictionary uploads so two concurrent uploads can't
+// interleave their writes to currentDictPath.
+var uploadMu sync.Mutex
+
+func checkReloadSecret(r *http.Request) bool {
+	secret := os.Getenv("RELOAD_SECRET")
+	if secret == "" {
+		return false
+	}
+	return r.Header.Get(reloadSecretHeader) == secret
+}
+
+// reloadErrorResponse is the structured body returned when a reload or
+// upload fails validation, including the row/column of a CSV parse
+// failure when available.
+type reloadErrorResponse struct {
+	Error  string `json:"error"`
+	Row    int    `json:"row,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+func writeReloadError(w http.ResponseWriter, status int, err error) {
+	resp := reloadErrorResponse{Error: err.Error()}
+	if pe, ok := err.(*DictParseError); ok {
+		resp.Row = pe.Row
+		resp.Column = pe.Column
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+// reloadFromPath parses the dictionary at path and, on success, swaps it
+// into globalIndex. On failure the previously loaded index keeps serving.
+func reloadFromPath(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rows, err := parseDictionary(file)
+	if err != nil {
+		return err
+	}
+
+	idx := &Index{}
+	idx.Build(rows)
+	globalIndex.Store(idx)
+	fmt.Printf("Dictionary reloaded from %s with %d items.\n", path, len(rows))
+	return nil
+}
+
+// reloadHandler re-parses the dictionary at currentDictPath and swaps it
+// in. It is guarded by a shared secret supplied via the RELOAD_SECRET env
+// var so it can't be triggered by an arbitrary caller.
+func reloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkReloadSecret(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := reloadFromPath(currentDictPath); err != nil {
+		writeReloadError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "reloaded"})
+}
+
+// dictionaryUploadHandler accepts a multipart CSV upload under the
+// "dictionary" field, validates it, persists it to currentDictPath, and
+// swaps it in the same way reloadHandler does. The previous dictionary
+// (on disk and in memory) is left untouched if validation fails.
+func dictionaryUploadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !checkReloadSecret(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	file, _, err := r.FormFile("dictionary")
+	if err != nil {
+		writeReloadError(w, http.StatusBadRequest, fmt.Errorf(`missing "dictionary" file: %w`, err))
+		return
+	}
+	defer file.Close()
+
+	body, err := io.ReadAll(file)
+	if err != nil {
+		writeReloadError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	rows, err := parseDictionary(bytes.NewReader(body))
+	if err != nil {
+		writeReloadError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	uploadMu.Lock()
+	defer uploadMu.Unlock()
+
+	if err := os.WriteFile(currentDictPath, body, 0o644); err != nil {
+		writeReloadError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	idx := &Index{}
+	idx.Build(rows)
+	globalIndex.Store(idx)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "uploaded"})
+}
+
+// watchDictionary starts an fsnotify watcher on path's directory and
+// triggers a debounced reload on write/create/rename events targeting
+// path, so editors that save via rename-into-place still trigger a
+// reload.
+func watchDictionary(path string) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("dictionary watcher disabled: %v", err)
+		return
+	}
+
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		log.Printf("dictionary watcher disabled: %v", err)
+		watcher.Close()
+		return
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		const debounceDelay = 250 * time.Millisecond
+		var debounce *time.Timer
+
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(path) {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+
+				if debounce != nil {
+					debounce.Stop()
+				}
+				debounce = time.AfterFunc(debounceDelay, func() {
+					if err := reloadFromPath(path); err != nil {
+						log.Printf("dictionary auto-reload failed: %v", err)
+					}
+				})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("dictionary watcher error: %v", err)
+			}
+		}
+	}()
+}